@@ -2,6 +2,9 @@ package adapter
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"time"
 
 	"github.com/sagernet/sing-box/log"
@@ -16,6 +19,9 @@ type Provider interface {
 	UpdatedAt() time.Time
 	IsUpdating() bool
 	HealthCheck() (map[string]uint16, error)
+	// Generation advances only when a reload actually changed the
+	// provider's outbound set.
+	Generation() uint64
 }
 
 type ProviderRemote interface {
@@ -42,3 +48,50 @@ type SubInfo struct {
 	Total    int64
 	Expire   int64
 }
+
+// OutboundIdentity returns a stable hash of an outbound's options.
+func OutboundIdentity(options option.Outbound) (string, error) {
+	content, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// OutboundDiff reports whether newOpts differs from oldOpts by per-tag
+// identity hash, plus the added/removed tags for logging. The full lists
+// are still passed to UpdateOutbounds; this only gates the no-op case.
+func OutboundDiff(oldOpts, newOpts []option.Outbound) (changed bool, added, removed []string, err error) {
+	oldHashes := make(map[string]string, len(oldOpts))
+	for _, opt := range oldOpts {
+		hash, hErr := OutboundIdentity(opt)
+		if hErr != nil {
+			return false, nil, nil, hErr
+		}
+		oldHashes[opt.Tag] = hash
+	}
+	newTags := make(map[string]struct{}, len(newOpts))
+	for _, opt := range newOpts {
+		newTags[opt.Tag] = struct{}{}
+		hash, hErr := OutboundIdentity(opt)
+		if hErr != nil {
+			return false, nil, nil, hErr
+		}
+		oldHash, existed := oldHashes[opt.Tag]
+		if !existed {
+			added = append(added, opt.Tag)
+			continue
+		}
+		if oldHash != hash {
+			changed = true
+		}
+	}
+	for _, opt := range oldOpts {
+		if _, loaded := newTags[opt.Tag]; !loaded {
+			removed = append(removed, opt.Tag)
+		}
+	}
+	changed = changed || len(added) > 0 || len(removed) > 0
+	return changed, added, removed, nil
+}
@@ -0,0 +1,74 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+func TestOutboundIdentity(t *testing.T) {
+	a := option.Outbound{Type: "direct", Tag: "a"}
+	b := option.Outbound{Type: "direct", Tag: "a"}
+	c := option.Outbound{Type: "trojan", Tag: "a"}
+
+	hashA, err := OutboundIdentity(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := OutboundIdentity(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashC, err := OutboundIdentity(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashA != hashB {
+		t.Errorf("identical options produced different hashes: %s != %s", hashA, hashB)
+	}
+	if hashA == hashC {
+		t.Errorf("differing options produced the same hash: %s", hashA)
+	}
+}
+
+func TestOutboundDiff(t *testing.T) {
+	unchanged := option.Outbound{Type: "direct", Tag: "unchanged"}
+	removed := option.Outbound{Type: "direct", Tag: "removed"}
+	modifiedOld := option.Outbound{Type: "direct", Tag: "modified"}
+	modifiedNew := option.Outbound{Type: "trojan", Tag: "modified"}
+	added := option.Outbound{Type: "direct", Tag: "added"}
+
+	oldOpts := []option.Outbound{unchanged, removed, modifiedOld}
+	newOpts := []option.Outbound{unchanged, modifiedNew, added}
+
+	changed, addedTags, removedTags, err := OutboundDiff(oldOpts, newOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected changed to be true")
+	}
+	if len(addedTags) != 1 || addedTags[0] != "added" {
+		t.Errorf("added = %v, want [added]", addedTags)
+	}
+	if len(removedTags) != 1 || removedTags[0] != "removed" {
+		t.Errorf("removed = %v, want [removed]", removedTags)
+	}
+}
+
+func TestOutboundDiffNoChange(t *testing.T) {
+	opts := []option.Outbound{
+		{Type: "direct", Tag: "a"},
+		{Type: "trojan", Tag: "b"},
+	}
+	changed, added, removed, err := OutboundDiff(opts, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Fatal("expected changed to be false for an identical list")
+	}
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("expected no added/removed tags, got added=%v removed=%v", added, removed)
+	}
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Subsystem identifies a component that can emit trace events, toggled at
+// startup via the SING_TRACE environment variable.
+type Subsystem uint32
+
+const (
+	SubsystemProvider Subsystem = 1 << iota
+	SubsystemSelector
+	SubsystemHealthCheck
+	SubsystemSubscription
+)
+
+var subsystemNames = map[string]Subsystem{
+	"provider":     SubsystemProvider,
+	"selector":     SubsystemSelector,
+	"healthcheck":  SubsystemHealthCheck,
+	"subscription": SubsystemSubscription,
+}
+
+var (
+	traceOnce sync.Once
+	traceMask Subsystem
+)
+
+// parseTrace turns a SING_TRACE value such as "provider,selector" or "all"
+// into a subsystem bitmask. Unknown tokens are ignored so a typo disables
+// only that subsystem rather than the whole env var.
+func parseTrace(value string) Subsystem {
+	if value == "" {
+		return 0
+	}
+	if value == "all" {
+		var mask Subsystem
+		for _, bit := range subsystemNames {
+			mask |= bit
+		}
+		return mask
+	}
+	var mask Subsystem
+	for _, name := range strings.Split(value, ",") {
+		if bit, loaded := subsystemNames[strings.TrimSpace(strings.ToLower(name))]; loaded {
+			mask |= bit
+		}
+	}
+	return mask
+}
+
+// TraceEnabled reports whether subsystem was requested via SING_TRACE. Call
+// sites that need to build an expensive trace payload (e.g. diffing large
+// option slices) should guard that work behind this check rather than
+// relying on Trace's internal check alone.
+func TraceEnabled(subsystem Subsystem) bool {
+	traceOnce.Do(func() {
+		traceMask = parseTrace(os.Getenv("SING_TRACE"))
+	})
+	return traceMask&subsystem != 0
+}
+
+// Trace emits a verbose debug line for subsystem when it is enabled via
+// SING_TRACE, bypassing the configured logger and log level entirely so
+// operators can debug provider/selector churn without recompiling.
+func Trace(subsystem Subsystem, message ...any) {
+	if !TraceEnabled(subsystem) {
+		return
+	}
+	fmt.Fprintln(os.Stderr, append([]any{"[trace]"}, message...)...)
+}
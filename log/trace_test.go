@@ -0,0 +1,27 @@
+package log
+
+import "testing"
+
+func TestParseTrace(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  Subsystem
+	}{
+		{"empty", "", 0},
+		{"single", "provider", SubsystemProvider},
+		{"multiple", "provider,selector", SubsystemProvider | SubsystemSelector},
+		{"case insensitive", "Provider,SELECTOR", SubsystemProvider | SubsystemSelector},
+		{"whitespace", " provider , selector ", SubsystemProvider | SubsystemSelector},
+		{"all", "all", SubsystemProvider | SubsystemSelector | SubsystemHealthCheck | SubsystemSubscription},
+		{"unknown token ignored", "provider,bogus", SubsystemProvider},
+		{"only unknown", "bogus", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseTrace(test.value); got != test.want {
+				t.Errorf("parseTrace(%q) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package group
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	"github.com/sagernet/sing-box/common/interrupt"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+func RegisterFallback(registry *outbound.Registry) {
+	outbound.Register[option.FallbackOutboundOptions](registry, C.TypeFallback, NewFallback)
+}
+
+var (
+	_ adapter.OutboundGroup             = (*Fallback)(nil)
+	_ adapter.ConnectionHandlerEx       = (*Fallback)(nil)
+	_ adapter.PacketConnectionHandlerEx = (*Fallback)(nil)
+)
+
+// Fallback is an outbound group that walks its members in the configured
+// order and activates the first one whose latest health-check latency is
+// below threshold, falling back further down the list as members degrade.
+type Fallback struct {
+	outbound.Adapter
+	ctx                          context.Context
+	outbound                     adapter.OutboundManager
+	cacheFile                    adapter.CacheFile
+	connection                   adapter.ConnectionManager
+	logger                       logger.ContextLogger
+	defaultTag                   string
+	providerFilter               *providerFilter
+	snapshot                     atomic.TypedValue[*groupSnapshot]
+	selected                     atomic.TypedValue[adapter.Outbound]
+	interruptGroup               *interrupt.Group
+	interruptExternalConnections bool
+
+	threshold uint16
+	interval  time.Duration
+	ticker    *time.Ticker
+	close     chan struct{}
+}
+
+func NewFallback(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.FallbackOutboundOptions) (adapter.Outbound, error) {
+	var (
+		err              error
+		exclude, include *regexp.Regexp
+	)
+	if options.Exclude != "" {
+		exclude, err = regexp.Compile(options.Exclude)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.Include != "" {
+		include, err = regexp.Compile(options.Include)
+		if err != nil {
+			return nil, err
+		}
+	}
+	interval := time.Duration(options.Interval)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	threshold := options.Threshold
+	if threshold == 0 {
+		threshold = 300
+	}
+	outboundManager := service.FromContext[adapter.OutboundManager](ctx)
+	providerManager := service.FromContext[adapter.ProviderManager](ctx)
+	fallback := &Fallback{
+		Adapter:                      outbound.NewAdapter(C.TypeFallback, tag, []string{N.NetworkTCP, N.NetworkUDP}, options.Outbounds),
+		ctx:                          ctx,
+		outbound:                     outboundManager,
+		connection:                   service.FromContext[adapter.ConnectionManager](ctx),
+		logger:                       logger,
+		defaultTag:                   options.Default,
+		providerFilter:               newProviderFilter(outboundManager, providerManager, options.Providers, options.UseAllProviders, include, exclude),
+		interruptGroup:               interrupt.NewGroup(),
+		interruptExternalConnections: options.InterruptExistConnections,
+		threshold:                    threshold,
+		interval:                     interval,
+		close:                        make(chan struct{}),
+	}
+	fallback.snapshot.Store(emptySnapshot)
+	return fallback, nil
+}
+
+func (s *Fallback) Network() []string {
+	selected := s.selected.Load()
+	if selected == nil {
+		return []string{N.NetworkTCP, N.NetworkUDP}
+	}
+	return selected.Network()
+}
+
+func (s *Fallback) Start() error {
+	if err := s.providerFilter.resolveProviders(); err != nil {
+		return err
+	}
+	if len(s.Dependencies())+len(s.providerFilter.providerTags) == 0 {
+		return E.New("missing outbound and provider tags")
+	}
+	if err := s.rebuild(""); err != nil {
+		return err
+	}
+	s.cacheFile = service.FromContext[adapter.CacheFile](s.ctx)
+	s.selectFirstHealthy()
+	s.ticker = time.NewTicker(s.interval)
+	go s.loopCheck()
+	return nil
+}
+
+func (s *Fallback) loopCheck() {
+	for {
+		select {
+		case <-s.close:
+			return
+		case <-s.ticker.C:
+			s.selectFirstHealthy()
+		}
+	}
+}
+
+// selectFirstHealthy walks the current snapshot's tags in order and
+// activates the first member whose latency is below threshold, so a
+// restored primary node reclaims traffic instead of leaving the group
+// stuck on whatever it fell back to.
+func (s *Fallback) selectFirstHealthy() {
+	latencies := s.providerFilter.healthCheck()
+	snapshot := s.snapshot.Load()
+	if tag, ok := firstHealthy(snapshot.tags, latencies, s.threshold); ok {
+		if current := s.selected.Load(); current == nil || current.Tag() != tag {
+			log.Trace(log.SubsystemSelector, "fallback[", s.Tag(), "] swapping to ", tag, " (", latencies[tag], "ms, threshold ", s.threshold, "ms)")
+		}
+		s.selectOutbound(tag)
+		return
+	}
+	if s.selected.Load() == nil {
+		s.selectOutbound(s.initialTag())
+	}
+}
+
+// firstHealthy returns the first tag in order whose latency is below
+// threshold, and ok=false if none qualify.
+func firstHealthy(tags []string, latencies map[string]uint16, threshold uint16) (tag string, ok bool) {
+	for _, t := range tags {
+		if latency, tested := latencies[t]; tested && latency < threshold {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+func (s *Fallback) initialTag() string {
+	snapshot := s.snapshot.Load()
+	if s.Tag() != "" && s.cacheFile != nil {
+		if selected := s.cacheFile.LoadSelected(s.Tag()); selected != "" {
+			if _, loaded := snapshot.outbounds[selected]; loaded {
+				return selected
+			}
+		}
+	}
+	if s.defaultTag != "" {
+		if _, loaded := snapshot.outbounds[s.defaultTag]; loaded {
+			return s.defaultTag
+		}
+	}
+	return snapshot.tags[0]
+}
+
+func (s *Fallback) selectOutbound(tag string) bool {
+	detour, loaded := s.snapshot.Load().outbounds[tag]
+	if !loaded {
+		return false
+	}
+	if s.selected.Swap(detour) == detour {
+		return true
+	}
+	if s.Tag() != "" && s.cacheFile != nil {
+		if err := s.cacheFile.StoreSelected(s.Tag(), tag); err != nil {
+			s.logger.Error("store selected: ", err)
+		}
+	}
+	s.interruptGroup.Interrupt(s.interruptExternalConnections)
+	return true
+}
+
+func (s *Fallback) Now() string {
+	selected := s.selected.Load()
+	if selected == nil {
+		return s.snapshot.Load().tags[0]
+	}
+	return selected.Tag()
+}
+
+func (s *Fallback) All() []string {
+	return s.snapshot.Load().tags
+}
+
+// SelectOutbound is not supported: Fallback always activates the highest
+// priority healthy member based on health-check latency.
+func (s *Fallback) SelectOutbound(tag string) bool {
+	return false
+}
+
+func (s *Fallback) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	conn, err := s.selected.Load().DialContext(ctx, network, destination)
+	if err != nil {
+		return nil, err
+	}
+	return s.interruptGroup.NewConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+}
+
+func (s *Fallback) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	conn, err := s.selected.Load().ListenPacket(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+	return s.interruptGroup.NewPacketConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+}
+
+func (s *Fallback) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	selected := s.selected.Load()
+	if outboundHandler, isHandler := selected.(adapter.ConnectionHandlerEx); isHandler {
+		outboundHandler.NewConnectionEx(ctx, conn, metadata, onClose)
+	} else {
+		s.connection.NewConnection(ctx, selected, conn, metadata, onClose)
+	}
+}
+
+func (s *Fallback) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	selected := s.selected.Load()
+	if outboundHandler, isHandler := selected.(adapter.PacketConnectionHandlerEx); isHandler {
+		outboundHandler.NewPacketConnectionEx(ctx, conn, metadata, onClose)
+	} else {
+		s.connection.NewPacketConnection(ctx, selected, conn, metadata, onClose)
+	}
+}
+
+func (s *Fallback) HasProvider(tag string) bool {
+	return s.providerFilter.hasProvider(tag)
+}
+
+func (s *Fallback) UpdateOutbounds(tag string) error {
+	_, loaded := s.providerFilter.providers[tag]
+	if !loaded {
+		return E.New("outbound provider not found: ", tag)
+	}
+	if !s.providerFilter.changed(tag) {
+		log.Trace(log.SubsystemProvider, "fallback[", s.Tag(), "] provider ", tag, " reported no diff, skipping rebuild")
+		return nil
+	}
+	if err := s.rebuild(tag); err != nil {
+		return nil
+	}
+	s.selectFirstHealthy()
+	return nil
+}
+
+// rebuild resolves the group's current members and atomically swaps them
+// into s.snapshot.
+func (s *Fallback) rebuild(updatedProviderTag string) error {
+	tags, outboundByTag, err := s.providerFilter.filterOutbounds(s.Dependencies(), s.defaultTag, updatedProviderTag)
+	if err != nil {
+		return err
+	}
+	s.snapshot.Store(&groupSnapshot{tags: tags, outbounds: outboundByTag})
+	return nil
+}
+
+func (s *Fallback) Close() error {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	close(s.close)
+	return nil
+}
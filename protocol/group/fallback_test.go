@@ -0,0 +1,58 @@
+package group
+
+import "testing"
+
+func TestFirstHealthy(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      []string
+		latencies map[string]uint16
+		threshold uint16
+		wantTag   string
+		wantOK    bool
+	}{
+		{
+			name:      "no tags",
+			tags:      nil,
+			latencies: map[string]uint16{},
+			threshold: 300,
+			wantOK:    false,
+		},
+		{
+			name:      "no member below threshold",
+			tags:      []string{"a", "b"},
+			latencies: map[string]uint16{"a": 400, "b": 500},
+			threshold: 300,
+			wantOK:    false,
+		},
+		{
+			name:      "untested members are skipped",
+			tags:      []string{"a", "b"},
+			latencies: map[string]uint16{"b": 100},
+			threshold: 300,
+			wantTag:   "b", wantOK: true,
+		},
+		{
+			name:      "first healthy member in order wins, not the lowest latency",
+			tags:      []string{"a", "b", "c"},
+			latencies: map[string]uint16{"a": 250, "b": 50, "c": 100},
+			threshold: 300,
+			wantTag:   "a", wantOK: true,
+		},
+		{
+			name:      "latency equal to threshold does not qualify",
+			tags:      []string{"a"},
+			latencies: map[string]uint16{"a": 300},
+			threshold: 300,
+			wantOK:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, ok := firstHealthy(tt.tags, tt.latencies, tt.threshold)
+			if ok != tt.wantOK || tag != tt.wantTag {
+				t.Fatalf("firstHealthy() = (%q, %v), want (%q, %v)", tag, ok, tt.wantTag, tt.wantOK)
+			}
+		})
+	}
+}
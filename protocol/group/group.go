@@ -0,0 +1,180 @@
+package group
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// providerFilter holds the provider/include/exclude machinery shared by the
+// outbound group implementations (Selector, URLTest, Fallback). It resolves
+// the configured provider tags into providers and turns them, together with
+// any directly listed outbounds, into the flat tag/outbound lists the groups
+// select over. mu guards outboundsCache/lastGeneration, since each provider
+// calls UpdateOutbounds from its own goroutine.
+type providerFilter struct {
+	outbound        adapter.OutboundManager
+	provider        adapter.ProviderManager
+	providers       map[string]adapter.Provider
+	providerTags    []string
+	useAllProviders bool
+	include         *regexp.Regexp
+	exclude         *regexp.Regexp
+	mu              sync.Mutex
+	outboundsCache  map[string][]adapter.Outbound
+	lastGeneration  map[string]uint64
+}
+
+func newProviderFilter(outboundManager adapter.OutboundManager, providerManager adapter.ProviderManager, providerTags []string, useAllProviders bool, include, exclude *regexp.Regexp) *providerFilter {
+	return &providerFilter{
+		outbound:        outboundManager,
+		provider:        providerManager,
+		providers:       make(map[string]adapter.Provider),
+		providerTags:    providerTags,
+		useAllProviders: useAllProviders,
+		include:         include,
+		exclude:         exclude,
+		outboundsCache:  make(map[string][]adapter.Outbound),
+		lastGeneration:  make(map[string]uint64),
+	}
+}
+
+// changed reports whether tag's provider has a new generation since the
+// last call, recording the generation seen as a side effect.
+func (f *providerFilter) changed(tag string) bool {
+	p, loaded := f.providers[tag]
+	if !loaded {
+		return true
+	}
+	generation := p.Generation()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if last, ok := f.lastGeneration[tag]; ok && last == generation {
+		return false
+	}
+	f.lastGeneration[tag] = generation
+	return true
+}
+
+// resolveProviders fills in f.providers from f.providerTags, or from every
+// registered provider when useAllProviders is set. It is called once from
+// each group's Start().
+func (f *providerFilter) resolveProviders() error {
+	if f.useAllProviders {
+		var providerTags []string
+		for _, p := range f.provider.Providers() {
+			providerTags = append(providerTags, p.Tag())
+			f.providers[p.Tag()] = p
+		}
+		f.providerTags = providerTags
+		return nil
+	}
+	for i, tag := range f.providerTags {
+		p, loaded := f.provider.Provider(tag)
+		if !loaded {
+			return E.New("outbound provider ", i, " not found: ", tag)
+		}
+		f.providers[tag] = p
+	}
+	return nil
+}
+
+func (f *providerFilter) hasProvider(tag string) bool {
+	if f.useAllProviders {
+		_, loaded := f.provider.Provider(tag)
+		return loaded
+	}
+	for _, providerTag := range f.providerTags {
+		if providerTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// filterOutbounds resolves dependencies (the group's directly listed
+// outbound tags) plus every member of the group's providers into a combined
+// tag list and tag->outbound map. updatedTag, when non-empty, is the single
+// provider tag that changed; its cached member list is rebuilt while every
+// other provider's cache is reused as-is.
+func (f *providerFilter) filterOutbounds(dependencies []string, defaultTag string, updatedTag string) ([]string, map[string]adapter.Outbound, error) {
+	var (
+		tags          = append([]string{}, dependencies...)
+		outboundByTag = make(map[string]adapter.Outbound)
+	)
+	for i, tag := range dependencies {
+		detour, loaded := f.outbound.Outbound(tag)
+		if !loaded {
+			return nil, nil, E.New("outbound ", i, " not found: ", tag)
+		}
+		outboundByTag[tag] = detour
+	}
+	if defaultTag != "" {
+		if _, loaded := outboundByTag[defaultTag]; !loaded {
+			return nil, nil, E.New("default outbound not found: ", defaultTag)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, providerTag := range f.providerTags {
+		if providerTag != updatedTag && f.outboundsCache[providerTag] != nil {
+			for _, detour := range f.outboundsCache[providerTag] {
+				tags = append(tags, detour.Tag())
+				outboundByTag[detour.Tag()] = detour
+			}
+			continue
+		}
+		p := f.providers[providerTag]
+		var cache []adapter.Outbound
+		for _, detour := range p.Outbounds() {
+			tag := detour.Tag()
+			if f.include != nil && !f.include.MatchString(tag) {
+				continue
+			}
+			if f.exclude != nil && f.exclude.MatchString(tag) {
+				continue
+			}
+			tags = append(tags, tag)
+			cache = append(cache, detour)
+			outboundByTag[tag] = detour
+		}
+		f.outboundsCache[providerTag] = cache
+	}
+	if len(tags) == 0 {
+		detour, _ := f.outbound.Outbound("Compatible")
+		tags = append(tags, detour.Tag())
+		outboundByTag[detour.Tag()] = detour
+	}
+	return tags, outboundByTag, nil
+}
+
+// groupSnapshot is an immutable, copy-on-write view of a group's resolved
+// tag list and tag->outbound map, swapped in atomically on rebuild so
+// readers never observe a partially rebuilt map.
+type groupSnapshot struct {
+	tags      []string
+	outbounds map[string]adapter.Outbound
+}
+
+var emptySnapshot = &groupSnapshot{}
+
+// healthCheck merges the HealthCheck() results of every provider this group
+// draws members from into a single tag->latency map.
+func (f *providerFilter) healthCheck() map[string]uint16 {
+	latencies := make(map[string]uint16)
+	for providerTag, p := range f.providers {
+		result, err := p.HealthCheck()
+		if err != nil {
+			log.Trace(log.SubsystemHealthCheck, "provider ", providerTag, " health check failed: ", err)
+			continue
+		}
+		for tag, latency := range result {
+			log.Trace(log.SubsystemHealthCheck, "provider ", providerTag, " probe ", tag, " -> ", latency, "ms")
+			latencies[tag] = latency
+		}
+	}
+	return latencies
+}
@@ -11,7 +11,6 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
-	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/atomic"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
@@ -38,19 +37,12 @@ type Selector struct {
 	cacheFile                    adapter.CacheFile
 	connection                   adapter.ConnectionManager
 	logger                       logger.ContextLogger
-	tags                         []string
 	defaultTag                   string
-	outbounds                    map[string]adapter.Outbound
-	outboundsCache               map[string][]adapter.Outbound
-	providers                    map[string]adapter.Provider
+	providerFilter               *providerFilter
+	snapshot                     atomic.TypedValue[*groupSnapshot]
 	selected                     atomic.TypedValue[adapter.Outbound]
 	interruptGroup               *interrupt.Group
 	interruptExternalConnections bool
-
-	include         *regexp.Regexp
-	exclude         *regexp.Regexp
-	providerTags    []string
-	useAllProviders bool
 }
 
 func NewSelector(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.SelectorOutboundOptions) (adapter.Outbound, error) {
@@ -70,26 +62,22 @@ func NewSelector(ctx context.Context, router adapter.Router, logger log.ContextL
 			return nil, err
 		}
 	}
-	outbound := &Selector{
+	outboundManager := service.FromContext[adapter.OutboundManager](ctx)
+	providerManager := service.FromContext[adapter.ProviderManager](ctx)
+	selector := &Selector{
 		Adapter:                      outbound.NewAdapter(C.TypeSelector, tag, []string{N.NetworkTCP, N.NetworkUDP}, options.Outbounds),
 		ctx:                          ctx,
-		outbound:                     service.FromContext[adapter.OutboundManager](ctx),
-		provider:                     service.FromContext[adapter.ProviderManager](ctx),
+		outbound:                     outboundManager,
+		provider:                     providerManager,
 		connection:                   service.FromContext[adapter.ConnectionManager](ctx),
 		logger:                       logger,
-		tags:                         options.Outbounds,
 		defaultTag:                   options.Default,
-		outbounds:                    make(map[string]adapter.Outbound),
-		outboundsCache:               make(map[string][]adapter.Outbound),
-		providers:                    make(map[string]adapter.Provider),
+		providerFilter:               newProviderFilter(outboundManager, providerManager, options.Providers, options.UseAllProviders, include, exclude),
 		interruptGroup:               interrupt.NewGroup(),
 		interruptExternalConnections: options.InterruptExistConnections,
-		include:                      include,
-		exclude:                      exclude,
-		providerTags:                 options.Providers,
-		useAllProviders:              options.UseAllProviders,
 	}
-	return outbound, nil
+	selector.snapshot.Store(emptySnapshot)
+	return selector, nil
 }
 
 func (s *Selector) Network() []string {
@@ -101,31 +89,15 @@ func (s *Selector) Network() []string {
 }
 
 func (s *Selector) Start() error {
-	if s.useAllProviders {
-		var providerTags []string
-		for _, provider := range s.provider.Providers() {
-			providerTags = append(providerTags, provider.Tag())
-			s.providers[provider.Tag()] = provider
-		}
-		s.providerTags = providerTags
-	} else {
-		for i, tag := range s.providerTags {
-			provider, loaded := s.provider.Provider(tag)
-			if !loaded {
-				E.New("outbound provider ", i, " not found: ", tag)
-			}
-			s.providers[tag] = provider
-		}
+	if err := s.providerFilter.resolveProviders(); err != nil {
+		return err
 	}
-	if len(s.tags)+len(s.providerTags) == 0 {
+	if len(s.Dependencies())+len(s.providerFilter.providerTags) == 0 {
 		return E.New("missing outbound and provider tags")
 	}
-	tags, outboundByTag, err := s.filterOutbounds("")
-	if err != nil {
+	if err := s.rebuild(""); err != nil {
 		return err
 	}
-	s.tags = tags
-	s.outbounds = outboundByTag
 	s.cacheFile = service.FromContext[adapter.CacheFile](s.ctx)
 	outbound, err := s.outboundSelect()
 	if err != nil {
@@ -138,23 +110,24 @@ func (s *Selector) Start() error {
 func (s *Selector) Now() string {
 	selected := s.selected.Load()
 	if selected == nil {
-		return s.tags[0]
+		return s.snapshot.Load().tags[0]
 	}
 	return selected.Tag()
 }
 
 func (s *Selector) All() []string {
-	return s.tags
+	return s.snapshot.Load().tags
 }
 
 func (s *Selector) SelectOutbound(tag string) bool {
-	detour, loaded := s.outbounds[tag]
+	detour, loaded := s.snapshot.Load().outbounds[tag]
 	if !loaded {
 		return false
 	}
 	if s.selected.Swap(detour) == detour {
 		return true
 	}
+	log.Trace(log.SubsystemSelector, "selector[", s.Tag(), "] swapped to ", tag)
 	if s.Tag() != "" {
 		cacheFile := service.FromContext[adapter.CacheFile](s.ctx)
 		if cacheFile != nil {
@@ -212,21 +185,22 @@ func RealTag(detour adapter.Outbound) string {
 }
 
 func (s *Selector) HasProvider(tag string) bool {
-	if s.useAllProviders {
-		_, loaded := s.provider.Provider(tag)
-		return loaded
-	}
-	return common.Contains(s.providerTags, tag)
+	return s.providerFilter.hasProvider(tag)
 }
 
 func (s *Selector) UpdateOutbounds(tag string) error {
-	_, loaded := s.providers[tag]
+	_, loaded := s.providerFilter.providers[tag]
 	if !loaded {
 		return E.New("outbound provider not found: ", tag)
 	}
-	tags, outboundByTag, _ := s.filterOutbounds(tag)
-	s.tags = tags
-	s.outbounds = outboundByTag
+	if !s.providerFilter.changed(tag) {
+		log.Trace(log.SubsystemProvider, "selector[", s.Tag(), "] provider ", tag, " reported no diff, skipping rebuild")
+		return nil
+	}
+	log.Trace(log.SubsystemProvider, "selector[", s.Tag(), "] provider ", tag, " updated")
+	if err := s.rebuild(tag); err != nil {
+		return nil
+	}
 	for _, p := range s.provider.Providers() {
 		if p.Tag() != tag && p.IsUpdating() {
 			return nil
@@ -240,63 +214,26 @@ func (s *Selector) UpdateOutbounds(tag string) error {
 	return nil
 }
 
-func (s *Selector) filterOutbounds(tag string) ([]string, map[string]adapter.Outbound, error) {
-	var (
-		tags          = s.Dependencies()
-		outboundByTag = make(map[string]adapter.Outbound)
-	)
-	for i, tag := range tags {
-		detour, loaded := s.outbound.Outbound(tag)
-		if !loaded {
-			return nil, nil, E.New("outbound ", i, " not found: ", tag)
-		}
-		outboundByTag[tag] = detour
-	}
-	if s.defaultTag != "" {
-		_, loaded := outboundByTag[s.defaultTag]
-		if !loaded {
-			return nil, nil, E.New("default outbound not found: ", s.defaultTag)
-		}
-	}
-	for _, providerTag := range s.providerTags {
-		if providerTag != tag && s.outboundsCache[providerTag] != nil {
-			for _, detour := range s.outboundsCache[providerTag] {
-				tags = append(tags, detour.Tag())
-				outboundByTag[detour.Tag()] = detour
-			}
-			continue
-		}
-		provider, _ := s.providers[providerTag]
-		var cache []adapter.Outbound
-		for _, detour := range provider.Outbounds() {
-			tag := detour.Tag()
-			if s.include != nil && !s.include.MatchString(tag) {
-				continue
-			}
-			if s.exclude != nil && s.exclude.MatchString(tag) {
-				continue
-			}
-			tags = append(tags, tag)
-			cache = append(cache, detour)
-			outboundByTag[tag] = detour
-		}
-		s.outboundsCache[providerTag] = cache
-	}
-	if len(tags) == 0 {
-		detour, _ := s.outbound.Outbound("Compatible")
-		tags = append(tags, detour.Tag())
-		outboundByTag[detour.Tag()] = detour
+// rebuild resolves the group's current members and atomically swaps them
+// into s.snapshot.
+func (s *Selector) rebuild(updatedProviderTag string) error {
+	tags, outboundByTag, err := s.providerFilter.filterOutbounds(s.Dependencies(), s.defaultTag, updatedProviderTag)
+	if err != nil {
+		return err
 	}
-	return tags, outboundByTag, nil
+	s.snapshot.Store(&groupSnapshot{tags: tags, outbounds: outboundByTag})
+	return nil
 }
 
 func (s *Selector) outboundSelect() (adapter.Outbound, error) {
+	snapshot := s.snapshot.Load()
 	if s.Tag() != "" {
 		if s.cacheFile != nil {
 			selected := s.cacheFile.LoadSelected(s.Tag())
 			if selected != "" {
-				detour, loaded := s.outbounds[selected]
+				detour, loaded := snapshot.outbounds[selected]
 				if loaded {
+					log.Trace(log.SubsystemSelector, "selector[", s.Tag(), "] resumed cached selection ", selected)
 					return detour, nil
 				}
 			}
@@ -304,12 +241,14 @@ func (s *Selector) outboundSelect() (adapter.Outbound, error) {
 	}
 
 	if s.defaultTag != "" {
-		detour, loaded := s.outbounds[s.defaultTag]
+		detour, loaded := snapshot.outbounds[s.defaultTag]
 		if !loaded {
 			return nil, E.New("default outbound not found: ", s.defaultTag)
 		}
+		log.Trace(log.SubsystemSelector, "selector[", s.Tag(), "] selected default ", s.defaultTag)
 		return detour, nil
 	}
 
-	return s.outbounds[s.tags[0]], nil
+	log.Trace(log.SubsystemSelector, "selector[", s.Tag(), "] selected first available ", snapshot.tags[0])
+	return snapshot.outbounds[snapshot.tags[0]], nil
 }
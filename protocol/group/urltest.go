@@ -0,0 +1,303 @@
+package group
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	"github.com/sagernet/sing-box/common/interrupt"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/atomic"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+func RegisterURLTest(registry *outbound.Registry) {
+	outbound.Register[option.URLTestOutboundOptions](registry, C.TypeURLTest, NewURLTest)
+}
+
+var (
+	_ adapter.OutboundGroup             = (*URLTest)(nil)
+	_ adapter.ConnectionHandlerEx       = (*URLTest)(nil)
+	_ adapter.PacketConnectionHandlerEx = (*URLTest)(nil)
+)
+
+// URLTest is an outbound group that auto-selects the lowest-latency member
+// of its providers, re-probing on a timer instead of waiting for a user to
+// call SelectOutbound.
+type URLTest struct {
+	outbound.Adapter
+	ctx                          context.Context
+	outbound                     adapter.OutboundManager
+	cacheFile                    adapter.CacheFile
+	connection                   adapter.ConnectionManager
+	logger                       logger.ContextLogger
+	defaultTag                   string
+	providerFilter               *providerFilter
+	snapshot                     atomic.TypedValue[*groupSnapshot]
+	selected                     atomic.TypedValue[adapter.Outbound]
+	interruptGroup               *interrupt.Group
+	interruptExternalConnections bool
+
+	tolerance uint16
+	interval  time.Duration
+	ticker    *time.Ticker
+	close     chan struct{}
+}
+
+func NewURLTest(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.URLTestOutboundOptions) (adapter.Outbound, error) {
+	var (
+		err              error
+		exclude, include *regexp.Regexp
+	)
+	if options.Exclude != "" {
+		exclude, err = regexp.Compile(options.Exclude)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.Include != "" {
+		include, err = regexp.Compile(options.Include)
+		if err != nil {
+			return nil, err
+		}
+	}
+	interval := time.Duration(options.Interval)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	tolerance := options.Tolerance
+	if tolerance == 0 {
+		tolerance = 50
+	}
+	outboundManager := service.FromContext[adapter.OutboundManager](ctx)
+	providerManager := service.FromContext[adapter.ProviderManager](ctx)
+	urlTest := &URLTest{
+		Adapter:                      outbound.NewAdapter(C.TypeURLTest, tag, []string{N.NetworkTCP, N.NetworkUDP}, options.Outbounds),
+		ctx:                          ctx,
+		outbound:                     outboundManager,
+		connection:                   service.FromContext[adapter.ConnectionManager](ctx),
+		logger:                       logger,
+		defaultTag:                   options.Default,
+		providerFilter:               newProviderFilter(outboundManager, providerManager, options.Providers, options.UseAllProviders, include, exclude),
+		interruptGroup:               interrupt.NewGroup(),
+		interruptExternalConnections: options.InterruptExistConnections,
+		tolerance:                    tolerance,
+		interval:                     interval,
+		close:                        make(chan struct{}),
+	}
+	urlTest.snapshot.Store(emptySnapshot)
+	return urlTest, nil
+}
+
+func (s *URLTest) Network() []string {
+	selected := s.selected.Load()
+	if selected == nil {
+		return []string{N.NetworkTCP, N.NetworkUDP}
+	}
+	return selected.Network()
+}
+
+func (s *URLTest) Start() error {
+	if err := s.providerFilter.resolveProviders(); err != nil {
+		return err
+	}
+	if len(s.Dependencies())+len(s.providerFilter.providerTags) == 0 {
+		return E.New("missing outbound and provider tags")
+	}
+	if err := s.rebuild(""); err != nil {
+		return err
+	}
+	s.cacheFile = service.FromContext[adapter.CacheFile](s.ctx)
+	s.selectBest()
+	s.ticker = time.NewTicker(s.interval)
+	go s.loopCheck()
+	return nil
+}
+
+func (s *URLTest) loopCheck() {
+	for {
+		select {
+		case <-s.close:
+			return
+		case <-s.ticker.C:
+			s.selectBest()
+		}
+	}
+}
+
+// selectBest re-runs health checks across the group's providers and swaps to
+// the lowest-latency member, but only when it beats the current selection by
+// more than tolerance — otherwise a marginally faster node would keep
+// flapping the active connection every interval.
+func (s *URLTest) selectBest() {
+	latencies := s.providerFilter.healthCheck()
+	snapshot := s.snapshot.Load()
+	bestTag, bestLatency, ok := pickLowestLatency(snapshot.tags, latencies)
+	if !ok {
+		if s.selected.Load() == nil {
+			s.selectOutbound(s.initialTag())
+		}
+		return
+	}
+	current := s.selected.Load()
+	if current != nil {
+		if currentLatency, tested := latencies[current.Tag()]; tested {
+			if currentLatency <= bestLatency+s.tolerance {
+				return
+			}
+		}
+	}
+	log.Trace(log.SubsystemSelector, "urltest[", s.Tag(), "] swapping to ", bestTag, " (", bestLatency, "ms, tolerance ", s.tolerance, "ms)")
+	s.selectOutbound(bestTag)
+}
+
+// pickLowestLatency returns the tag with the lowest tested latency among
+// tags, and ok=false if none were tested.
+func pickLowestLatency(tags []string, latencies map[string]uint16) (tag string, latency uint16, ok bool) {
+	for _, t := range tags {
+		l, tested := latencies[t]
+		if !tested {
+			continue
+		}
+		if !ok || l < latency {
+			tag, latency, ok = t, l, true
+		}
+	}
+	return
+}
+
+func (s *URLTest) initialTag() string {
+	snapshot := s.snapshot.Load()
+	if s.Tag() != "" && s.cacheFile != nil {
+		if selected := s.cacheFile.LoadSelected(s.Tag()); selected != "" {
+			if _, loaded := snapshot.outbounds[selected]; loaded {
+				return selected
+			}
+		}
+	}
+	if s.defaultTag != "" {
+		if _, loaded := snapshot.outbounds[s.defaultTag]; loaded {
+			return s.defaultTag
+		}
+	}
+	return snapshot.tags[0]
+}
+
+func (s *URLTest) selectOutbound(tag string) bool {
+	detour, loaded := s.snapshot.Load().outbounds[tag]
+	if !loaded {
+		return false
+	}
+	if s.selected.Swap(detour) == detour {
+		return true
+	}
+	if s.Tag() != "" && s.cacheFile != nil {
+		if err := s.cacheFile.StoreSelected(s.Tag(), tag); err != nil {
+			s.logger.Error("store selected: ", err)
+		}
+	}
+	s.interruptGroup.Interrupt(s.interruptExternalConnections)
+	return true
+}
+
+func (s *URLTest) Now() string {
+	selected := s.selected.Load()
+	if selected == nil {
+		return s.snapshot.Load().tags[0]
+	}
+	return selected.Tag()
+}
+
+func (s *URLTest) All() []string {
+	return s.snapshot.Load().tags
+}
+
+// SelectOutbound is not supported: URLTest always picks its own member based
+// on health-check latency.
+func (s *URLTest) SelectOutbound(tag string) bool {
+	return false
+}
+
+func (s *URLTest) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	conn, err := s.selected.Load().DialContext(ctx, network, destination)
+	if err != nil {
+		return nil, err
+	}
+	return s.interruptGroup.NewConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+}
+
+func (s *URLTest) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	conn, err := s.selected.Load().ListenPacket(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+	return s.interruptGroup.NewPacketConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+}
+
+func (s *URLTest) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	selected := s.selected.Load()
+	if outboundHandler, isHandler := selected.(adapter.ConnectionHandlerEx); isHandler {
+		outboundHandler.NewConnectionEx(ctx, conn, metadata, onClose)
+	} else {
+		s.connection.NewConnection(ctx, selected, conn, metadata, onClose)
+	}
+}
+
+func (s *URLTest) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	selected := s.selected.Load()
+	if outboundHandler, isHandler := selected.(adapter.PacketConnectionHandlerEx); isHandler {
+		outboundHandler.NewPacketConnectionEx(ctx, conn, metadata, onClose)
+	} else {
+		s.connection.NewPacketConnection(ctx, selected, conn, metadata, onClose)
+	}
+}
+
+func (s *URLTest) HasProvider(tag string) bool {
+	return s.providerFilter.hasProvider(tag)
+}
+
+func (s *URLTest) UpdateOutbounds(tag string) error {
+	_, loaded := s.providerFilter.providers[tag]
+	if !loaded {
+		return E.New("outbound provider not found: ", tag)
+	}
+	if !s.providerFilter.changed(tag) {
+		log.Trace(log.SubsystemProvider, "urltest[", s.Tag(), "] provider ", tag, " reported no diff, skipping rebuild")
+		return nil
+	}
+	if err := s.rebuild(tag); err != nil {
+		return nil
+	}
+	s.selectBest()
+	return nil
+}
+
+// rebuild resolves the group's current members and atomically swaps them
+// into s.snapshot.
+func (s *URLTest) rebuild(updatedProviderTag string) error {
+	tags, outboundByTag, err := s.providerFilter.filterOutbounds(s.Dependencies(), s.defaultTag, updatedProviderTag)
+	if err != nil {
+		return err
+	}
+	s.snapshot.Store(&groupSnapshot{tags: tags, outbounds: outboundByTag})
+	return nil
+}
+
+func (s *URLTest) Close() error {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	close(s.close)
+	return nil
+}
@@ -0,0 +1,53 @@
+package group
+
+import "testing"
+
+func TestPickLowestLatency(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		latencies   map[string]uint16
+		wantTag     string
+		wantLatency uint16
+		wantOK      bool
+	}{
+		{
+			name:      "no tags",
+			tags:      nil,
+			latencies: map[string]uint16{},
+			wantOK:    false,
+		},
+		{
+			name:      "no tested members",
+			tags:      []string{"a", "b"},
+			latencies: map[string]uint16{},
+			wantOK:    false,
+		},
+		{
+			name:      "untested members are skipped",
+			tags:      []string{"a", "b", "c"},
+			latencies: map[string]uint16{"b": 50},
+			wantTag:   "b", wantLatency: 50, wantOK: true,
+		},
+		{
+			name:      "lowest latency wins",
+			tags:      []string{"a", "b", "c"},
+			latencies: map[string]uint16{"a": 100, "b": 30, "c": 60},
+			wantTag:   "b", wantLatency: 30, wantOK: true,
+		},
+		{
+			name:      "tie keeps the first tag in order",
+			tags:      []string{"a", "b"},
+			latencies: map[string]uint16{"a": 50, "b": 50},
+			wantTag:   "a", wantLatency: 50, wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, latency, ok := pickLowestLatency(tt.tags, tt.latencies)
+			if ok != tt.wantOK || tag != tt.wantTag || latency != tt.wantLatency {
+				t.Fatalf("pickLowestLatency() = (%q, %d, %v), want (%q, %d, %v)", tag, latency, ok, tt.wantTag, tt.wantLatency, tt.wantOK)
+			}
+		})
+	}
+}
@@ -41,6 +41,7 @@ type ProviderLocal struct {
 	lastUpdated time.Time
 	watcher     *fswatch.Watcher
 	updating    atomic.Bool
+	generation  atomic.Uint64
 }
 
 func NewProviderLocal(ctx context.Context, router adapter.Router, logFactory log.Factory, tag string, options option.ProviderLocalOptions) (adapter.Provider, error) {
@@ -119,8 +120,21 @@ func (s *ProviderLocal) reloadFile(path string) error {
 	if err != nil {
 		return err
 	}
-	s.UpdateOutbounds(s.lastOutOpts, outboundOpts)
+	changed, added, removed, err := adapter.OutboundDiff(s.lastOutOpts, outboundOpts)
+	if err != nil {
+		return err
+	}
+	if log.TraceEnabled(log.SubsystemProvider) {
+		log.Trace(log.SubsystemProvider, "provider/local[", s.Tag(), "] reload ", path, " added=", added, " removed=", removed)
+	}
+	lastOutOpts := s.lastOutOpts
 	s.lastOutOpts = outboundOpts
+	if !changed {
+		log.Trace(log.SubsystemProvider, "provider/local[", s.Tag(), "] reload ", path, " no diff, skipping rebuild")
+		return nil
+	}
+	s.UpdateOutbounds(lastOutOpts, outboundOpts)
+	s.generation.Add(1)
 	return nil
 }
 
@@ -128,6 +142,10 @@ func (s *ProviderLocal) IsUpdating() bool {
 	return s.updating.Load()
 }
 
+func (s *ProviderLocal) Generation() uint64 {
+	return s.generation.Load()
+}
+
 func (s *ProviderLocal) Close() error {
 	return common.Close(&s.Adapter, common.PtrOrNil(s.watcher))
 }
@@ -0,0 +1,299 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/provider"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/provider/parser"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/sing/service/filemanager"
+	"github.com/sagernet/sing/service/pause"
+)
+
+func RegisterProvider(registry *provider.Registry) {
+	provider.Register[option.ProviderRemoteOptions](registry, C.ProviderTypeRemote, NewProviderRemote)
+}
+
+var (
+	_ adapter.Provider       = (*ProviderRemote)(nil)
+	_ adapter.ProviderRemote = (*ProviderRemote)(nil)
+	_ adapter.Service        = (*ProviderRemote)(nil)
+)
+
+type ProviderRemote struct {
+	provider.Adapter
+	ctx        context.Context
+	cancel     context.CancelFunc
+	logger     log.ContextLogger
+	outbound   adapter.OutboundManager
+	httpClient *http.Client
+
+	url       string
+	interval  time.Duration
+	userAgent string
+	headers   map[string]string
+	detour    string
+	cachePath string
+
+	lastOutOpts []option.Outbound
+	lastUpdated time.Time
+	updating    atomic.Bool
+	generation  atomic.Uint64
+
+	access  sync.Mutex
+	etag    string
+	lastMod string
+	subInfo adapter.SubInfo
+}
+
+func NewProviderRemote(ctx context.Context, router adapter.Router, logFactory log.Factory, tag string, options option.ProviderRemoteOptions) (adapter.Provider, error) {
+	if tag == "" {
+		return nil, E.New("provider tag is required")
+	}
+	if options.URL == "" {
+		return nil, E.New("provider url is required")
+	}
+	var (
+		outbound     = service.FromContext[adapter.OutboundManager](ctx)
+		pauseManager = service.FromContext[pause.Manager](ctx)
+		logger       = logFactory.NewLogger(F.ToString("provider/remote", "[", tag, "]"))
+	)
+	interval := time.Duration(options.Interval)
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	cachePath := options.CacheFile
+	if cachePath == "" {
+		cachePath = tag + ".subscription"
+	}
+	cachePath = filemanager.BasePath(ctx, cachePath)
+	cachePath, _ = filepath.Abs(cachePath)
+	ctx, cancel := context.WithCancel(ctx)
+	p := &ProviderRemote{
+		Adapter:   provider.NewAdapter(ctx, router, outbound, pauseManager, logFactory, logger, tag, C.ProviderTypeRemote, options.HealthCheck),
+		ctx:       ctx,
+		cancel:    cancel,
+		logger:    logger,
+		outbound:  outbound,
+		url:       options.URL,
+		interval:  interval,
+		userAgent: options.UserAgent,
+		headers:   options.Headers,
+		detour:    options.DialerOptions.Detour,
+		cachePath: cachePath,
+	}
+	p.httpClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: p.dialContext,
+		},
+	}
+	if content, err := os.ReadFile(cachePath); err == nil {
+		if outboundOpts, pErr := parser.ParseSubscription(ctx, string(content)); pErr == nil {
+			p.UpdateOutbounds(nil, outboundOpts)
+			p.lastOutOpts = outboundOpts
+			p.generation.Add(1)
+			if fileInfo, sErr := os.Stat(cachePath); sErr == nil {
+				p.lastUpdated = fileInfo.ModTime()
+			}
+		}
+	}
+	return p, nil
+}
+
+// dialContext routes the subscription download through the configured
+// dial-detour outbound, if any, so the update itself can ride an existing
+// proxy chain instead of always hitting the network directly.
+func (p *ProviderRemote) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if p.detour == "" {
+		return (&net.Dialer{}).DialContext(ctx, network, address)
+	}
+	detour, loaded := p.outbound.Outbound(p.detour)
+	if !loaded {
+		return nil, E.New("dial detour not found: ", p.detour)
+	}
+	return detour.DialContext(ctx, network, M.ParseSocksaddr(address))
+}
+
+func (p *ProviderRemote) Start() error {
+	err := p.Adapter.Start()
+	if err != nil {
+		return err
+	}
+	if p.lastOutOpts == nil {
+		if err := p.Update(); err != nil {
+			return E.Cause(err, "initial subscription update")
+		}
+	}
+	go p.loopUpdate()
+	return nil
+}
+
+func (p *ProviderRemote) loopUpdate() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Update(); err != nil {
+				p.logger.Error(E.Cause(err, "update subscription"))
+			}
+		}
+	}
+}
+
+func (p *ProviderRemote) UpdatedAt() time.Time {
+	return p.lastUpdated
+}
+
+func (p *ProviderRemote) IsUpdating() bool {
+	return p.updating.Load()
+}
+
+func (p *ProviderRemote) Generation() uint64 {
+	return p.generation.Load()
+}
+
+func (p *ProviderRemote) SubInfo() adapter.SubInfo {
+	p.access.Lock()
+	defer p.access.Unlock()
+	return p.subInfo
+}
+
+// Update downloads the subscription, honouring ETag/Last-Modified so an
+// unchanged remote costs a single round-trip, and only rebuilds outbounds
+// when the body actually changed.
+func (p *ProviderRemote) Update() error {
+	if p.updating.Swap(true) {
+		return nil
+	}
+	defer func() {
+		p.updating.Store(false)
+		p.UpdateGroups()
+	}()
+	request, err := http.NewRequestWithContext(p.ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return err
+	}
+	if p.userAgent != "" {
+		request.Header.Set("User-Agent", p.userAgent)
+	} else {
+		request.Header.Set("User-Agent", "sing-box")
+	}
+	for key, value := range p.headers {
+		request.Header.Set(key, value)
+	}
+	p.access.Lock()
+	if p.etag != "" {
+		request.Header.Set("If-None-Match", p.etag)
+	}
+	if p.lastMod != "" {
+		request.Header.Set("If-Modified-Since", p.lastMod)
+	}
+	p.access.Unlock()
+	response, err := p.httpClient.Do(request)
+	if err != nil {
+		log.Trace(log.SubsystemSubscription, "provider/remote[", p.Tag(), "] GET ", p.url, " failed: ", err)
+		return err
+	}
+	defer response.Body.Close()
+	log.Trace(log.SubsystemSubscription, "provider/remote[", p.Tag(), "] GET ", p.url, " -> ", response.StatusCode)
+	if response.StatusCode == http.StatusNotModified {
+		p.lastUpdated = time.Now()
+		return nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return E.New("unexpected status: ", response.StatusCode)
+	}
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	outboundOpts, err := parser.ParseSubscription(p.ctx, string(content))
+	if err != nil {
+		return err
+	}
+	changed, added, removed, err := adapter.OutboundDiff(p.lastOutOpts, outboundOpts)
+	if err != nil {
+		return err
+	}
+	p.parseSubInfo(response.Header)
+	p.access.Lock()
+	p.etag = response.Header.Get("Etag")
+	p.lastMod = response.Header.Get("Last-Modified")
+	p.access.Unlock()
+	if err := os.WriteFile(p.cachePath, content, 0o644); err != nil {
+		p.logger.Warn(E.Cause(err, "write subscription cache"))
+	}
+	if log.TraceEnabled(log.SubsystemProvider) {
+		log.Trace(log.SubsystemProvider, "provider/remote[", p.Tag(), "] subscription added=", added, " removed=", removed)
+	}
+	lastOutOpts := p.lastOutOpts
+	p.lastOutOpts = outboundOpts
+	p.lastUpdated = time.Now()
+	if !changed {
+		log.Trace(log.SubsystemProvider, "provider/remote[", p.Tag(), "] no outbound diff, skipping rebuild")
+		return nil
+	}
+	p.UpdateOutbounds(lastOutOpts, outboundOpts)
+	p.generation.Add(1)
+	return nil
+}
+
+// parseSubInfo reads the `subscription-userinfo` header in the form
+// `upload=…; download=…; total=…; expire=…`, as used by SIP008 and most
+// Clash-style subscription backends.
+func (p *ProviderRemote) parseSubInfo(header http.Header) {
+	value := header.Get("subscription-userinfo")
+	if value == "" {
+		return
+	}
+	var info adapter.SubInfo
+	for _, part := range strings.Split(value, ";") {
+		key, number, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		numberValue, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "upload":
+			info.Upload = numberValue
+		case "download":
+			info.Download = numberValue
+		case "total":
+			info.Total = numberValue
+		case "expire":
+			info.Expire = numberValue
+		}
+	}
+	p.access.Lock()
+	p.subInfo = info
+	p.access.Unlock()
+}
+
+func (p *ProviderRemote) Close() error {
+	p.cancel()
+	return common.Close(&p.Adapter)
+}
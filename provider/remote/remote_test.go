@@ -0,0 +1,55 @@
+package remote
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+func TestParseSubInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   adapter.SubInfo
+	}{
+		{
+			name:   "empty header leaves subInfo unset",
+			header: "",
+			want:   adapter.SubInfo{},
+		},
+		{
+			name:   "all fields",
+			header: "upload=100; download=200; total=1000; expire=1700000000",
+			want:   adapter.SubInfo{Upload: 100, Download: 200, Total: 1000, Expire: 1700000000},
+		},
+		{
+			name:   "case insensitive keys and extra whitespace",
+			header: " Upload = 1 ; DOWNLOAD=2 ;Total=3",
+			want:   adapter.SubInfo{Upload: 1, Download: 2, Total: 3},
+		},
+		{
+			name:   "unknown key is ignored",
+			header: "upload=1; bogus=2",
+			want:   adapter.SubInfo{Upload: 1},
+		},
+		{
+			name:   "malformed entry is ignored",
+			header: "upload=1; download",
+			want:   adapter.SubInfo{Upload: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ProviderRemote{}
+			header := http.Header{}
+			if tt.header != "" {
+				header.Set("subscription-userinfo", tt.header)
+			}
+			p.parseSubInfo(header)
+			if got := p.SubInfo(); got != tt.want {
+				t.Fatalf("SubInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}